@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/muesli/termenv"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const rowTagNew = "[NEW]"
+
+// diffKey identifies a row across two snapshots.
+type diffKey struct {
+	namespace string
+	gvk       string
+	name      string
+}
+
+func rowDiffKey(tr *tableRow) diffKey {
+	return diffKey{namespace: tr.Namespace, gvk: tr.GVK.String(), name: tr.Name}
+}
+
+func rowTargetDiffKey(tr *tableRow) diffKey {
+	return diffKey{namespace: tr.Namespace, gvk: tr.TargetGVK.String(), name: tr.TargetName}
+}
+
+// Diff renders t side-by-side against prev, a previously-saved structured
+// snapshot loaded via --compare, adding delta columns for the CPU/memory
+// recommendation. Rows are matched on (namespace, GVK, name), falling back
+// to (namespace, target GVK, target name) for VPAs that were renamed but
+// still point at the same target. Rows only present in t are tagged [NEW];
+// rows only present in prev are rendered with strikethrough styling.
+func (t table) Diff(prev table, w io.Writer, flags *Flags) error {
+	prevRows := prev.flatten()
+	byKey := make(map[diffKey]*tableRow, len(prevRows))
+	byTarget := make(map[diffKey]*tableRow, len(prevRows))
+	for _, pr := range prevRows {
+		byKey[rowDiffKey(pr)] = pr
+		byTarget[rowTargetDiffKey(pr)] = pr
+	}
+	matched := make(map[*tableRow]bool, len(prevRows))
+
+	tw := newKubectlTableWriter(w)
+	if !flags.NoHeaders {
+		headers := []string{hdrName, hdrMode, hdrTarget, hdrCPUDifference, hdrCPUDelta, hdrCPUDeltaPct, hdrMemDifference, hdrMemDelta, hdrMemDeltaPct}
+		if flags.ShowNamespace {
+			headers = append([]string{hdrNamespace}, headers...)
+		}
+		tw.SetHeader(headers)
+	}
+
+	for _, tr := range t.flatten() {
+		pr, ok := byKey[rowDiffKey(tr)]
+		if !ok {
+			pr, ok = byTarget[rowTargetDiffKey(tr)]
+		}
+		if ok {
+			matched[pr] = true
+		}
+		tw.Append(t.diffRowData(tr, pr, flags))
+	}
+	for _, pr := range prevRows {
+		if !matched[pr] {
+			tw.Append(t.removedRowData(pr, flags))
+		}
+	}
+
+	tw.Render()
+	return nil
+}
+
+// diffRowData renders tr alongside its counterpart pr from the previous
+// snapshot (nil if tr is new).
+func (t table) diffRowData(tr, pr *tableRow, flags *Flags) []string {
+	name := tr.Name
+	if pr == nil {
+		name = fmt.Sprintf("%s %s", name, rowTagNew)
+	}
+
+	row := []string{}
+	if flags.ShowNamespace {
+		row = append(row, tr.Namespace)
+	}
+	row = append(row, name, tr.Mode, tr.TargetName, formatPercentage(tr.CPUDifference, flags.NoColors))
+
+	var prevCPU, prevMem *resource.Quantity
+	if pr != nil {
+		prevCPU, prevMem = pr.Recommendations.CPU, pr.Recommendations.Memory
+	}
+	cpuDelta, cpuDeltaPct := quantityDelta(tr.Recommendations.CPU, prevCPU)
+	memDelta, memDeltaPct := quantityDelta(tr.Recommendations.Memory, prevMem)
+
+	row = append(row, formatQuantity(cpuDelta), formatPercentage(cpuDeltaPct, flags.NoColors))
+	row = append(row, formatPercentage(tr.MemoryDifference, flags.NoColors))
+	row = append(row, formatQuantity(memDelta), formatPercentage(memDeltaPct, flags.NoColors))
+	return row
+}
+
+// removedRowData renders a row that existed in the previous snapshot but
+// not in the current one, struck through to show it disappeared. Its %
+// diff columns show pr's last known values (also struck through); there's
+// no current row to delta against, so the Δ columns stay unset.
+func (t table) removedRowData(pr *tableRow, flags *Flags) []string {
+	strike := func(s string) string { return termenv.String(s).CrossOut().String() }
+
+	row := []string{}
+	if flags.ShowNamespace {
+		row = append(row, strike(pr.Namespace))
+	}
+	row = append(row,
+		strike(pr.Name), strike(pr.Mode), strike(pr.TargetName),
+		strike(formatPercentage(pr.CPUDifference, flags.NoColors)), strike(tableUnsetCell), strike(tableUnsetCell),
+		strike(formatPercentage(pr.MemoryDifference, flags.NoColors)), strike(tableUnsetCell), strike(tableUnsetCell),
+	)
+	return row
+}
+
+// quantityDelta returns cur-prev and its signed % change vs. prev. It
+// returns (nil, nil) when either side is missing, using compareQuantities
+// only to short-circuit the common case where both sides are identical.
+func quantityDelta(cur, prev *resource.Quantity) (*resource.Quantity, *float64) {
+	if cur == nil || prev == nil {
+		return nil, nil
+	}
+	if compareQuantities(cur, prev) == 0 {
+		zero := resource.Quantity{}
+		pct := 0.0
+		return &zero, &pct
+	}
+
+	delta := cur.DeepCopy()
+	delta.Sub(*prev)
+
+	prevF, curF := prev.AsApproximateFloat64(), cur.AsApproximateFloat64()
+	pct := math.Inf(1)
+	if curF < prevF {
+		pct = math.Inf(-1)
+	}
+	if prevF != 0 {
+		pct = (curF - prevF) / prevF * 100
+	}
+	return &delta, &pct
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/template"
+
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// outputFormat selects how a table is rendered: the default kubectl-style
+// ASCII table, or one of the structured formats.
+type outputFormat string
+
+const (
+	outputFormatTable      outputFormat = "table"
+	outputFormatJSON       outputFormat = "json"
+	outputFormatYAML       outputFormat = "yaml"
+	outputFormatJSONPath   outputFormat = "jsonpath"
+	outputFormatGoTemplate outputFormat = "go-template"
+	outputFormatPrometheus outputFormat = "prometheus"
+)
+
+// String implements the pflag.Value interface.
+func (of outputFormat) String() string { return string(of) }
+
+// Type implements the pflag.Value interface.
+func (of *outputFormat) Type() string { return "string" }
+
+// Set implements the pflag.Value interface.
+func (of *outputFormat) Set(s string) error {
+	switch outputFormat(s) {
+	case outputFormatTable, outputFormatJSON, outputFormatYAML, outputFormatJSONPath, outputFormatGoTemplate, outputFormatPrometheus:
+		*of = outputFormat(s)
+		return nil
+	default:
+		return fmt.Errorf("must be one of %q, %q, %q, %q, %q or %q",
+			outputFormatTable, outputFormatJSON, outputFormatYAML, outputFormatJSONPath, outputFormatGoTemplate, outputFormatPrometheus)
+	}
+}
+
+// statValue is the total/mean/median/stddev/percentile set for a single
+// statistic, as emitted in the structured output modes.
+type statValue struct {
+	Total       string            `json:"total"`
+	Mean        string            `json:"mean"`
+	Median      string            `json:"median"`
+	StdDev      string            `json:"stdDev"`
+	Percentiles map[string]string `json:"percentiles,omitempty"`
+}
+
+// statsPayload mirrors the rows printed by table.printStats, keyed by
+// name instead of position so it survives round-tripping through JSON/YAML.
+type statsPayload struct {
+	CPURecommendations    statValue `json:"cpuRecommendations"`
+	CPURequests           statValue `json:"cpuRequests"`
+	MemoryRecommendations statValue `json:"memoryRecommendations"`
+	MemoryRequests        statValue `json:"memoryRequests"`
+	CPUDiffPercent        statValue `json:"cpuDiffPercent"`
+	MemoryDiffPercent     statValue `json:"memoryDiffPercent"`
+}
+
+func (t table) buildStatsPayload(flags *Flags) *statsPayload {
+	statFuncs := []tableStatFn{
+		t.sumQuantities,
+		t.meanQuantities,
+		t.medianQuantities,
+	}
+	percentiles := statsPercentiles(flags)
+
+	out := &statsPayload{}
+	for _, row := range t.statRows() {
+		values := make([]string, 0, len(statFuncs))
+		for _, fn := range statFuncs {
+			values = append(values, formatStatQuantity(fn(row.getter), row.asBytes))
+		}
+
+		sorted := t.collectQuantities(row.getter)
+		sv := statValue{
+			Total:  values[0],
+			Mean:   values[1],
+			Median: values[2],
+			StdDev: formatStatQuantity(stddevQuantities(sorted, t.meanQuantities(row.getter)), row.asBytes),
+		}
+		if len(percentiles) > 0 {
+			sv.Percentiles = make(map[string]string, len(percentiles))
+			for _, p := range percentiles {
+				sv.Percentiles[fmt.Sprintf("p%d", p)] = formatStatQuantity(quantileQuantities(sorted, float64(p)/100), row.asBytes)
+			}
+		}
+
+		switch row.jsonKey {
+		case "cpuRecommendations":
+			out.CPURecommendations = sv
+		case "cpuRequests":
+			out.CPURequests = sv
+		case "memoryRecommendations":
+			out.MemoryRecommendations = sv
+		case "memoryRequests":
+			out.MemoryRequests = sv
+		case "cpuDiffPercent":
+			out.CPUDiffPercent = sv
+		case "memoryDiffPercent":
+			out.MemoryDiffPercent = sv
+		}
+	}
+	return out
+}
+
+// tablePayload is the structured representation of a table emitted by the
+// json, yaml, jsonpath and go-template output modes.
+type tablePayload struct {
+	Rows   []*tableRow    `json:"rows"`
+	Stats  *statsPayload  `json:"stats,omitempty"`
+	Errors []resolveError `json:"errors,omitempty"`
+}
+
+func (t table) payload(flags *Flags, errs []resolveError) tablePayload {
+	p := tablePayload{Rows: []*tableRow(t)}
+	if flags.ShowStats {
+		p.Stats = t.buildStatsPayload(flags)
+	}
+	if flags.ShowStats || flags.ShowErrors {
+		p.Errors = errs
+	}
+	return p
+}
+
+// printStructured renders the table using the format requested through
+// flags.Output.
+func (t table) printStructured(w io.Writer, flags *Flags, errs []resolveError) error {
+	payload := t.payload(flags, errs)
+
+	switch flags.Output {
+	case outputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(payload)
+	case outputFormatYAML:
+		b, err := yaml.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("marshaling yaml output: %w", err)
+		}
+		_, err = w.Write(b)
+		return err
+	case outputFormatJSONPath:
+		return printJSONPath(w, flags.JSONPathTemplate, payload)
+	case outputFormatGoTemplate:
+		return printGoTemplate(w, flags.GoTemplate, payload)
+	default:
+		return fmt.Errorf("unsupported output format %q", flags.Output)
+	}
+}
+
+// printJSONPath evaluates the kubectl-style jsonpath expression expr
+// against payload and writes the result to w.
+func printJSONPath(w io.Writer, expr string, payload tablePayload) error {
+	jp := jsonpath.New("out").AllowMissingKeys(true)
+	if err := jp.Parse(expr); err != nil {
+		return fmt.Errorf("parsing jsonpath template: %w", err)
+	}
+
+	// jsonpath.Execute walks generic maps/slices, so round-trip the
+	// payload through JSON rather than reflecting over the Go structs.
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var data interface{}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	if err := jp.Execute(w, data); err != nil {
+		return fmt.Errorf("executing jsonpath template: %w", err)
+	}
+	_, err = w.Write([]byte("\n"))
+	return err
+}
+
+// printGoTemplate executes the given text/template against payload and
+// writes the result to w, matching kubectl's `-o go-template` behavior.
+func printGoTemplate(w io.Writer, text string, payload tablePayload) error {
+	tpl, err := template.New("out").Parse(text)
+	if err != nil {
+		return fmt.Errorf("parsing go-template: %w", err)
+	}
+	if err := tpl.Execute(w, payload); err != nil {
+		return fmt.Errorf("executing go-template: %w", err)
+	}
+	_, err = w.Write([]byte("\n"))
+	return err
+}
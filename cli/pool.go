@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"sync"
+)
+
+// resolveIdentity is enough to name a VPA in an error message without
+// requiring the full vpa.VPA object to have resolved successfully.
+type resolveIdentity struct {
+	Namespace string
+	Name      string
+}
+
+// resolveError records a single VPA that failed target resolution or diff
+// computation, so one bad VPA doesn't abort a run over the whole cluster.
+type resolveError struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Error     string `json:"error"`
+}
+
+// resolveFunc resolves the i-th VPA (target lookup plus request/
+// recommendation diff) into a table row.
+type resolveFunc func(ctx context.Context, i int) (*tableRow, error)
+
+// resolvePool runs a resolveFunc over a bounded number of goroutines.
+type resolvePool struct {
+	concurrency int
+}
+
+// newResolvePool returns a pool sized to concurrency, defaulting to
+// min(16, n) when concurrency is <= 0 (unset), and never exceeding n.
+func newResolvePool(concurrency, n int) *resolvePool {
+	if concurrency <= 0 {
+		concurrency = 16
+	}
+	if concurrency > n {
+		concurrency = n
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &resolvePool{concurrency: concurrency}
+}
+
+// Run fans identities out across the pool's workers, calling fn for each
+// index. It stops handing out new work once ctx is canceled (e.g. by a
+// SIGINT-derived context), but lets in-flight calls finish. Results are
+// returned in the original identity order; failures are collected as
+// resolveErrors instead of aborting the run.
+func (p *resolvePool) Run(ctx context.Context, identities []resolveIdentity, fn resolveFunc) (table, []resolveError) {
+	n := len(identities)
+	rows := make([]*tableRow, n)
+	errs := make([]*resolveError, n)
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := 0; i < n; i++ {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(p.concurrency)
+	for w := 0; w < p.concurrency; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				row, err := fn(ctx, i)
+				if err != nil {
+					errs[i] = &resolveError{
+						Namespace: identities[i].Namespace,
+						Name:      identities[i].Name,
+						Error:     err.Error(),
+					}
+					continue
+				}
+				rows[i] = row
+			}
+		}()
+	}
+	wg.Wait()
+
+	out := make(table, 0, n)
+	var outErrs []resolveError
+	for i := 0; i < n; i++ {
+		switch {
+		case errs[i] != nil:
+			outErrs = append(outErrs, *errs[i])
+		case rows[i] != nil:
+			out = append(out, rows[i])
+		}
+	}
+	return out, outErrs
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestResolvePoolRun(t *testing.T) {
+	const n = 10
+	identities := make([]resolveIdentity, n)
+	for i := range identities {
+		identities[i] = resolveIdentity{Namespace: "ns", Name: fmt.Sprintf("vpa-%d", i)}
+	}
+
+	pool := newResolvePool(3, n)
+	rows, errs := pool.Run(context.Background(), identities, func(_ context.Context, i int) (*tableRow, error) {
+		// Every third identity fails to resolve.
+		if i%3 == 0 {
+			return nil, fmt.Errorf("resolve error for %d", i)
+		}
+		return &tableRow{Name: identities[i].Name}, nil
+	})
+
+	wantErrs := 0
+	for i := 0; i < n; i++ {
+		if i%3 == 0 {
+			wantErrs++
+		}
+	}
+	if len(errs) != wantErrs {
+		t.Fatalf("expected %d errors, got %d", wantErrs, len(errs))
+	}
+	if len(rows) != n-wantErrs {
+		t.Fatalf("expected %d rows, got %d", n-wantErrs, len(rows))
+	}
+
+	// Rows must come back in the original identity order.
+	wantNames := make([]string, 0, len(rows))
+	for i := 0; i < n; i++ {
+		if i%3 != 0 {
+			wantNames = append(wantNames, identities[i].Name)
+		}
+	}
+	for i, row := range rows {
+		if row.Name != wantNames[i] {
+			t.Errorf("row %d: expected name %q, got %q", i, wantNames[i], row.Name)
+		}
+	}
+
+	// Errors must name the identity they came from, in index order too.
+	wantErrNames := make([]string, 0, len(errs))
+	for i := 0; i < n; i++ {
+		if i%3 == 0 {
+			wantErrNames = append(wantErrNames, identities[i].Name)
+		}
+	}
+	for i, e := range errs {
+		if e.Name != wantErrNames[i] {
+			t.Errorf("error %d: expected name %q, got %q", i, wantErrNames[i], e.Name)
+		}
+	}
+}
+
+func TestResolvePoolRunPreCanceledContext(t *testing.T) {
+	const n = 50
+	identities := make([]resolveIdentity, n)
+	for i := range identities {
+		identities[i] = resolveIdentity{Namespace: "ns", Name: fmt.Sprintf("vpa-%d", i)}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var mu sync.Mutex
+	called := make(map[int]bool, n)
+
+	rows, errs := newResolvePool(4, n).Run(ctx, identities, func(_ context.Context, i int) (*tableRow, error) {
+		mu.Lock()
+		called[i] = true
+		mu.Unlock()
+		if i%2 == 0 {
+			return nil, fmt.Errorf("resolve error for %d", i)
+		}
+		return &tableRow{Name: identities[i].Name}, nil
+	})
+
+	// A pre-canceled context must not hang and must not produce more
+	// results than calls that actually happened.
+	if got := len(rows) + len(errs); got > len(called) {
+		t.Fatalf("got %d results (rows+errs) but only %d calls were made", got, len(called))
+	}
+	for i, row := range rows {
+		if row.Name == "" || !called[indexOfName(identities, row.Name)] {
+			t.Errorf("row %d: %q was not recorded as called", i, row.Name)
+		}
+	}
+}
+
+func indexOfName(identities []resolveIdentity, name string) int {
+	for i, id := range identities {
+		if id.Name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// printPrometheus renders t as Prometheus text exposition, one gauge per
+// (namespace, name, target, mode) VPA, so a scrape sidecar can alert on
+// drift without re-parsing the colored ASCII table.
+func (t table) printPrometheus(w io.Writer) error {
+	rows := t.flatten()
+
+	metrics := []struct {
+		name string
+		help string
+		get  func(tr *tableRow) *resource.Quantity
+	}{
+		{"vpa_recommendation_cpu_cores", "Recommended CPU request, in cores.", func(tr *tableRow) *resource.Quantity { return tr.Recommendations.CPU }},
+		{"vpa_request_cpu_cores", "Current CPU request, in cores.", func(tr *tableRow) *resource.Quantity { return tr.Requests.CPU }},
+		{"vpa_recommendation_memory_bytes", "Recommended memory request, in bytes.", func(tr *tableRow) *resource.Quantity { return tr.Recommendations.Memory }},
+		{"vpa_request_memory_bytes", "Current memory request, in bytes.", func(tr *tableRow) *resource.Quantity { return tr.Requests.Memory }},
+	}
+
+	for _, m := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name); err != nil {
+			return err
+		}
+		for _, tr := range rows {
+			q := m.get(tr)
+			if q == nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "%s{%s} %s\n", m.name, promLabels(tr), q.AsDec().String()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "# HELP vpa_diff_percent Signed percentage difference between the current request and the recommendation.\n# TYPE vpa_diff_percent gauge\n"); err != nil {
+		return err
+	}
+	for _, tr := range rows {
+		for _, diff := range []struct {
+			resource string
+			value    *float64
+		}{
+			{"cpu", tr.CPUDifference},
+			{"memory", tr.MemoryDifference},
+		} {
+			if diff.value == nil {
+				continue
+			}
+			labels := fmt.Sprintf(`%s,resource="%s"`, promLabels(tr), diff.resource)
+			if _, err := fmt.Fprintf(w, "vpa_diff_percent{%s} %g\n", labels, *diff.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flatten returns every row in t, including child rows, since Prometheus
+// exposition has no notion of the ASCII table's tree indentation.
+func (t table) flatten() []*tableRow {
+	rows := make([]*tableRow, 0, len(t))
+	for _, tr := range t {
+		rows = append(rows, tr)
+		rows = append(rows, tr.Children...)
+	}
+	return rows
+}
+
+func promLabels(tr *tableRow) string {
+	return strings.Join([]string{
+		fmt.Sprintf(`namespace=%q`, tr.Namespace),
+		fmt.Sprintf(`name=%q`, tr.Name),
+		fmt.Sprintf(`target_kind=%q`, tr.TargetGVK.Kind),
+		fmt.Sprintf(`target_name=%q`, tr.TargetName),
+		fmt.Sprintf(`mode=%q`, tr.Mode),
+	}, ",")
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"gopkg.in/inf.v0"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// defaultStatsPercentiles is used when --stats-percentiles is not set.
+var defaultStatsPercentiles = []int{50, 90, 95, 99}
+
+// statsPercentiles returns the percentiles to render in the stats table,
+// falling back to defaultStatsPercentiles when the user didn't pick any.
+func statsPercentiles(flags *Flags) []int {
+	if len(flags.StatsPercentiles) == 0 {
+		return defaultStatsPercentiles
+	}
+	return flags.StatsPercentiles
+}
+
+// collectQuantities gathers the non-nil quantities addressed by getter and
+// returns them sorted ascending, ready for quantileQuantities.
+func (t table) collectQuantities(getter func(i int) *resource.Quantity) []*resource.Quantity {
+	values := make([]*resource.Quantity, 0, len(t))
+	for i := range t {
+		if v := getter(i); v != nil {
+			values = append(values, v)
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return compareQuantities(values[i], values[j]) < 0 })
+	return values
+}
+
+// quantileQuantities computes the p-th quantile (0 <= p <= 1) of sorted,
+// using linear interpolation between neighboring ranks (Type-7 / the
+// method used by NumPy's and R's default `quantile`). sorted must already
+// be sorted ascending.
+func quantileQuantities(sorted []*resource.Quantity, p float64) *resource.Quantity {
+	n := len(sorted)
+	switch {
+	case n == 0:
+		return nil
+	case n == 1:
+		return sorted[0]
+	}
+
+	rank := p * float64(n-1)
+	lower := int(rank)
+	if lower >= n-1 {
+		return sorted[n-1]
+	}
+	frac := rank - float64(lower)
+
+	lowDec := sorted[lower].AsDec()
+	highDec := sorted[lower+1].AsDec()
+
+	fracDec := new(inf.Dec)
+	fracDec.SetString(strconv.FormatFloat(frac, 'f', -1, 64))
+
+	step := new(inf.Dec).Sub(highDec, lowDec)
+	step.Mul(step, fracDec)
+
+	result := new(inf.Dec).Add(lowDec, step)
+	return resource.NewDecimalQuantity(*result, resource.DecimalSI)
+}
+
+// stddevQuantities computes the population standard deviation of values
+// around mean: sqrt(sum((x-mean)^2)/n). inf.Dec has no square root, so the
+// variance is computed exactly in decimal and only the final sqrt is done
+// in floating point.
+func stddevQuantities(values []*resource.Quantity, mean *resource.Quantity) *resource.Quantity {
+	if len(values) == 0 || mean == nil {
+		return nil
+	}
+
+	meanDec := mean.AsDec()
+	sumSq := new(inf.Dec)
+	for _, v := range values {
+		diff := new(inf.Dec).Sub(v.AsDec(), meanDec)
+		diff.Mul(diff, diff)
+		sumSq.Add(sumSq, diff)
+	}
+
+	variance := new(inf.Dec).QuoRound(sumSq, inf.NewDec(int64(len(values)), 0), 20, inf.RoundHalfEven)
+	varianceFloat, err := strconv.ParseFloat(variance.String(), 64)
+	if err != nil {
+		return nil
+	}
+
+	stddev := new(inf.Dec)
+	stddev.SetString(strconv.FormatFloat(math.Sqrt(varianceFloat), 'f', -1, 64))
+	return resource.NewDecimalQuantity(*stddev, resource.DecimalSI)
+}
+
+// floatToQuantity wraps a percentage-style float (such as tableRow's
+// CPUDifference/MemoryDifference) as a *resource.Quantity so the same
+// quantile/stddev routines can be reused for the % diff distribution.
+func floatToQuantity(f *float64) *resource.Quantity {
+	if f == nil {
+		return nil
+	}
+	tmp := new(inf.Dec)
+	tmp.SetString(strconv.FormatFloat(*f, 'f', -1, 64))
+	return resource.NewDecimalQuantity(*tmp, resource.DecimalSI)
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"math"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func quantityPtr(s string) *resource.Quantity {
+	q := resource.MustParse(s)
+	return &q
+}
+
+func quantityApprox(t *testing.T, q *resource.Quantity) float64 {
+	t.Helper()
+	if q == nil {
+		t.Fatal("unexpected nil quantity")
+	}
+	return q.AsApproximateFloat64()
+}
+
+func TestQuantileQuantities(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []*resource.Quantity
+		p      float64
+		want   float64
+	}{
+		{
+			name:   "empty",
+			sorted: nil,
+			p:      0.5,
+			want:   math.NaN(), // handled separately: expect nil result
+		},
+		{
+			name:   "odd count median",
+			sorted: []*resource.Quantity{quantityPtr("1"), quantityPtr("2"), quantityPtr("3")},
+			p:      0.5,
+			want:   2,
+		},
+		{
+			name:   "even count median",
+			sorted: []*resource.Quantity{quantityPtr("1"), quantityPtr("2"), quantityPtr("3"), quantityPtr("4")},
+			p:      0.5,
+			want:   2.5,
+		},
+		{
+			name:   "p0 is the minimum",
+			sorted: []*resource.Quantity{quantityPtr("1"), quantityPtr("2"), quantityPtr("3"), quantityPtr("4")},
+			p:      0,
+			want:   1,
+		},
+		{
+			name:   "p100 is the maximum",
+			sorted: []*resource.Quantity{quantityPtr("1"), quantityPtr("2"), quantityPtr("3"), quantityPtr("4")},
+			p:      1,
+			want:   4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := quantileQuantities(tt.sorted, tt.p)
+			if tt.name == "empty" {
+				if got != nil {
+					t.Errorf("expected nil for an empty input, got %v", got)
+				}
+				return
+			}
+			if gotF := quantityApprox(t, got); math.Abs(gotF-tt.want) > 1e-9 {
+				t.Errorf("expected %v, got %v", tt.want, gotF)
+			}
+		})
+	}
+}
+
+func TestStddevQuantities(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []*resource.Quantity
+		mean   *resource.Quantity
+		want   float64
+	}{
+		{
+			name:   "two values",
+			values: []*resource.Quantity{quantityPtr("0.1"), quantityPtr("0.3")},
+			mean:   quantityPtr("0.2"),
+			want:   0.1,
+		},
+		{
+			name:   "all equal has zero stddev",
+			values: []*resource.Quantity{quantityPtr("5"), quantityPtr("5"), quantityPtr("5")},
+			mean:   quantityPtr("5"),
+			want:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stddevQuantities(tt.values, tt.mean)
+			if gotF := quantityApprox(t, got); math.Abs(gotF-tt.want) > 1e-9 {
+				t.Errorf("expected %v, got %v", tt.want, gotF)
+			}
+		})
+	}
+
+	t.Run("empty values returns nil", func(t *testing.T) {
+		if got := stddevQuantities(nil, quantityPtr("1")); got != nil {
+			t.Errorf("expected nil, got %v", got)
+		}
+	})
+
+	t.Run("nil mean returns nil", func(t *testing.T) {
+		if got := stddevQuantities([]*resource.Quantity{quantityPtr("1")}, nil); got != nil {
+			t.Errorf("expected nil, got %v", got)
+		}
+	})
+}
+
+// TestMeanStddevSameDenominator locks in the fix for the mean/stddev
+// denominator mismatch: meanQuantities and stddevQuantities must describe
+// the same population (the non-nil values) even when some rows have a nil
+// quantity for this column, e.g. an Off-mode VPA with no Requests.
+func TestMeanStddevSameDenominator(t *testing.T) {
+	values := []*resource.Quantity{nil, quantityPtr("0.1"), nil, quantityPtr("0.3")}
+	tbl := make(table, len(values))
+	getter := func(i int) *resource.Quantity { return values[i] }
+
+	mean := tbl.meanQuantities(getter)
+	if gotF := quantityApprox(t, mean); math.Abs(gotF-0.2) > 1e-9 {
+		t.Errorf("expected mean 0.2 over the 2 non-nil rows, got %v", gotF)
+	}
+
+	sorted := tbl.collectQuantities(getter)
+	stddev := stddevQuantities(sorted, mean)
+	if gotF := quantityApprox(t, stddev); math.Abs(gotF-0.1) > 1e-9 {
+		t.Errorf("expected stddev 0.1 over the same 2 non-nil rows, got %v", gotF)
+	}
+}
+
+func TestMeanQuantitiesEmptyTable(t *testing.T) {
+	var tbl table
+	if got := tbl.meanQuantities(func(i int) *resource.Quantity { return nil }); got != nil {
+		t.Errorf("expected nil mean for an empty table, got %v", got)
+	}
+}
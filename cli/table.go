@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -50,17 +51,58 @@ func (so *sortOrder) Set(s string) error {
 
 // tableRow represents a single row of a table.
 type tableRow struct {
-	Name             string
-	Namespace        string
-	GVK              schema.GroupVersionKind
-	Mode             string
-	TargetName       string
-	TargetGVK        schema.GroupVersionKind
-	Requests         vpa.ResourceQuantities
-	Recommendations  vpa.ResourceQuantities
-	CPUDifference    *float64
-	MemoryDifference *float64
-	Children         []*tableRow
+	Name             string                  `json:"name"`
+	Namespace        string                  `json:"namespace"`
+	GVK              schema.GroupVersionKind `json:"gvk"`
+	Mode             string                  `json:"mode"`
+	TargetName       string                  `json:"targetName"`
+	TargetGVK        schema.GroupVersionKind `json:"targetGVK"`
+	Requests         vpa.ResourceQuantities  `json:"requests"`
+	Recommendations  vpa.ResourceQuantities  `json:"recommendations"`
+	CPUDifference    *float64                `json:"cpuDifferencePercent,omitempty"`
+	MemoryDifference *float64                `json:"memoryDifferencePercent,omitempty"`
+	// CPUDiffHistory and MemoryDiffHistory hold the % diff observed at
+	// each snapshot under --history-dir, oldest first, with the current
+	// CPUDifference/MemoryDifference as the last element. They're only
+	// populated when --trend is set.
+	CPUDiffHistory    []float64   `json:"cpuDiffHistory,omitempty"`
+	MemoryDiffHistory []float64   `json:"memoryDiffHistory,omitempty"`
+	Children          []*tableRow `json:"children,omitempty"`
+}
+
+// resourceQuantitiesJSON mirrors vpa.ResourceQuantities with lower-camel
+// json tags, since that upstream type has none of its own.
+type resourceQuantitiesJSON struct {
+	CPU    *resource.Quantity `json:"cpu"`
+	Memory *resource.Quantity `json:"memory"`
+}
+
+// gvkJSON mirrors schema.GroupVersionKind with lower-camel json tags, since
+// that upstream type has none of its own.
+type gvkJSON struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+}
+
+// MarshalJSON renders tableRow with the same camelCase convention as the
+// rest of the structured output, rather than letting the GVK and resource
+// quantity fields fall back to their upstream types' PascalCase field names.
+func (tr tableRow) MarshalJSON() ([]byte, error) {
+	type alias tableRow
+	return json.Marshal(struct {
+		alias
+		GVK             gvkJSON                `json:"gvk"`
+		TargetGVK       gvkJSON                `json:"targetGVK"`
+		Requests        resourceQuantitiesJSON `json:"requests"`
+		Recommendations resourceQuantitiesJSON `json:"recommendations"`
+	}{
+		alias:           alias(tr),
+		GVK:             gvkJSON{Group: tr.GVK.Group, Version: tr.GVK.Version, Kind: tr.GVK.Kind},
+		TargetGVK:       gvkJSON{Group: tr.TargetGVK.Group, Version: tr.TargetGVK.Version, Kind: tr.TargetGVK.Kind},
+		Requests:        resourceQuantitiesJSON{CPU: tr.Requests.CPU, Memory: tr.Requests.Memory},
+		Recommendations: resourceQuantitiesJSON{CPU: tr.Recommendations.CPU, Memory: tr.Recommendations.Memory},
+	})
 }
 
 func (tr tableRow) toTableData(flags *Flags, isChild bool) []string {
@@ -100,9 +142,19 @@ func (tr tableRow) toTableData(flags *Flags, isChild bool) []string {
 		)
 	}
 	rowData = append(rowData, formatPercentage(tr.MemoryDifference, flags.NoColors))
+	if flags.Trend {
+		rowData = append(rowData, tr.trendGlyph(tr.CPUDiffHistory), tr.trendGlyph(tr.MemoryDiffHistory))
+	}
 	return rowData
 }
 
+// trendGlyph runs the KZA trend filter over history and returns just the
+// arrow glyph, for display in the table's trend columns.
+func (tr tableRow) trendGlyph(history []float64) string {
+	_, arrow := computeTrend(history)
+	return arrow
+}
+
 type (
 	table    []*tableRow
 	lessFunc func(r1, r2 *tableRow) int
@@ -140,10 +192,27 @@ const (
 	hdrMemRequest    = "Memory Request" // the Memory request of the pod
 	hdrMemTarget     = "Memory Target"  // the Memory recommendation target
 	hdrMemDifference = "% Memory Diff"  // the % difference between memory request/recommendation
+	hdrCPUTrend      = "CPU Trend"      // the KZA-smoothed trend of the % CPU diff across snapshots
+	hdrMemTrend      = "Memory Trend"   // the KZA-smoothed trend of the % memory diff across snapshots
+	hdrCPUDelta      = "Δ CPU"          // the change in CPU recommendation vs. the --compare snapshot
+	hdrCPUDeltaPct   = "Δ CPU %"        // the % change in CPU recommendation vs. the --compare snapshot
+	hdrMemDelta      = "Δ Memory"       // the change in memory recommendation vs. the --compare snapshot
+	hdrMemDeltaPct   = "Δ Memory %"     // the % change in memory recommendation vs. the --compare snapshot
 )
 
-// Print writes the table to w.
-func (t table) Print(w io.Writer, flags *Flags) error {
+// Print writes the table to w, using flags.Output to select between the
+// default kubectl-style ASCII table and the structured output modes
+// (json, yaml, jsonpath, go-template). errs holds any per-VPA resolution
+// failures collected while building t; they're rendered in a final
+// "Errors" section when flags.ShowStats or flags.ShowErrors is set.
+func (t table) Print(w io.Writer, flags *Flags, errs []resolveError) error {
+	switch flags.Output {
+	case outputFormatJSON, outputFormatYAML, outputFormatJSONPath, outputFormatGoTemplate:
+		return t.printStructured(w, flags, errs)
+	case outputFormatPrometheus:
+		return t.printPrometheus(w)
+	}
+
 	tw := newKubectlTableWriter(w)
 
 	if !flags.NoHeaders {
@@ -160,6 +229,9 @@ func (t table) Print(w io.Writer, flags *Flags) error {
 			headers = append(headers, hdrMemRequest, hdrMemTarget)
 		}
 		headers = append(headers, hdrMemDifference)
+		if flags.Trend {
+			headers = append(headers, hdrCPUTrend, hdrMemTrend)
+		}
 		tw.SetHeader(headers)
 	}
 	for _, row := range t {
@@ -171,18 +243,58 @@ func (t table) Print(w io.Writer, flags *Flags) error {
 	tw.Render()
 
 	if flags.ShowStats {
-		_, err := os.Stdout.WriteString("\n")
-		if err != nil {
+		if _, err := os.Stdout.WriteString("\n"); err != nil {
 			return err
 		}
-		return t.printStats(w)
+		if err := t.printStats(w, statsPercentiles(flags)); err != nil {
+			return err
+		}
+	}
+	if (flags.ShowStats || flags.ShowErrors) && len(errs) > 0 {
+		if _, err := os.Stdout.WriteString("\n"); err != nil {
+			return err
+		}
+		return printResolveErrors(w, errs)
+	}
+	return nil
+}
+
+// printResolveErrors renders the "Errors" section: one row per VPA whose
+// target resolution or diff computation failed.
+func printResolveErrors(w io.Writer, errs []resolveError) error {
+	tw := newKubectlTableWriter(w)
+	tw.SetHeader([]string{"Namespace", "Name", "Error"})
+	for _, e := range errs {
+		tw.Append([]string{e.Namespace, e.Name, e.Error})
 	}
+	tw.Render()
 	return nil
 }
 
 type tableStatFn func(column func(i int) *resource.Quantity) *resource.Quantity
 
-func (t table) printStats(w io.Writer) error {
+// tableStatRow describes one row of the stats table: which quantity it
+// pulls out of the table, and whether that quantity should be rendered
+// as a byte size rather than a plain decimal.
+type tableStatRow struct {
+	name    string
+	jsonKey string
+	getter  func(i int) *resource.Quantity
+	asBytes bool
+}
+
+func (t table) statRows() []tableStatRow {
+	return []tableStatRow{
+		{"CPU Recommendations (# cores)", "cpuRecommendations", func(i int) *resource.Quantity { return t[i].Recommendations.CPU }, false},
+		{"CPU Requests (# cores)", "cpuRequests", func(i int) *resource.Quantity { return t[i].Requests.CPU }, false},
+		{"MEM Recommendations (IEC/SI)", "memoryRecommendations", func(i int) *resource.Quantity { return t[i].Recommendations.Memory }, true},
+		{"MEM Requests (IEC/SI)", "memoryRequests", func(i int) *resource.Quantity { return t[i].Requests.Memory }, true},
+		{"% CPU Diff", "cpuDiffPercent", func(i int) *resource.Quantity { return floatToQuantity(t[i].CPUDifference) }, false},
+		{"% Memory Diff", "memoryDiffPercent", func(i int) *resource.Quantity { return floatToQuantity(t[i].MemoryDifference) }, false},
+	}
+}
+
+func (t table) printStats(w io.Writer, percentiles []int) error {
 	tw := newKubectlTableWriter(w)
 
 	statFuncs := []tableStatFn{
@@ -190,45 +302,46 @@ func (t table) printStats(w io.Writer) error {
 		t.meanQuantities,
 		t.medianQuantities,
 	}
-	rows := []struct {
-		name    string
-		getter  func(i int) *resource.Quantity
-		asBytes bool
-	}{
-		{"CPU Recommendations (# cores)", func(i int) *resource.Quantity { return t[i].Recommendations.CPU }, false},
-		{"CPU Requests (# cores)", func(i int) *resource.Quantity { return t[i].Requests.CPU }, false},
-		{"MEM Recommendations (IEC/SI)", func(i int) *resource.Quantity { return t[i].Recommendations.Memory }, true},
-		{"MEM Requests (IEC/SI)", func(i int) *resource.Quantity { return t[i].Requests.Memory }, true},
+	headers := []string{"Description", "Total", "Mean", "Median", "StdDev"}
+	for _, p := range percentiles {
+		headers = append(headers, fmt.Sprintf("P%d", p))
 	}
-	for _, row := range rows {
-		values := make([]string, 0, len(statFuncs))
+
+	for _, row := range t.statRows() {
+		values := make([]string, 0, len(statFuncs)+1+len(percentiles))
 		for _, fn := range statFuncs {
-			q := fn(row.getter)
-
-			var str string
-			if q == nil {
-				str = tableUnsetCell
-			} else {
-				if row.asBytes {
-					tmp := inf.Dec{}
-					tmp.Round(q.AsDec(), 0, inf.RoundUp)
-					big := tmp.UnscaledBig()
-					str = humanize.BigIBytes(big) + "/" + humanize.BigBytes(big)
-					str = strings.ReplaceAll(str, " ", "")
-				} else {
-					str = q.AsDec().String()
-				}
-			}
-			values = append(values, str)
+			values = append(values, formatStatQuantity(fn(row.getter), row.asBytes))
+		}
+
+		sorted := t.collectQuantities(row.getter)
+		values = append(values, formatStatQuantity(stddevQuantities(sorted, t.meanQuantities(row.getter)), row.asBytes))
+		for _, p := range percentiles {
+			values = append(values, formatStatQuantity(quantileQuantities(sorted, float64(p)/100), row.asBytes))
 		}
 		tw.Append(append([]string{row.name}, values...))
 	}
-	tw.SetHeader([]string{"Description", "Total", "Mean", "Median"})
+	tw.SetHeader(headers)
 	tw.Render()
 
 	return nil
 }
 
+// formatStatQuantity renders q the same way the stats table does: as a
+// plain decimal, or as a humanized IEC/SI byte size when asBytes is set.
+func formatStatQuantity(q *resource.Quantity, asBytes bool) string {
+	if q == nil {
+		return tableUnsetCell
+	}
+	if !asBytes {
+		return q.AsDec().String()
+	}
+	tmp := inf.Dec{}
+	tmp.Round(q.AsDec(), 0, inf.RoundUp)
+	big := tmp.UnscaledBig()
+	str := humanize.BigIBytes(big) + "/" + humanize.BigBytes(big)
+	return strings.ReplaceAll(str, " ", "")
+}
+
 func (t table) sumQuantities(column func(i int) *resource.Quantity) *resource.Quantity {
 	var sum resource.Quantity
 	for i := range t {
@@ -240,11 +353,23 @@ func (t table) sumQuantities(column func(i int) *resource.Quantity) *resource.Qu
 	return &sum
 }
 
+// meanQuantities averages the non-nil values addressed by column. It
+// divides by the count of non-nil values (the same set stddevQuantities
+// is handed), not len(t), so rows with a nil value for this column (an
+// "Off"-mode VPA with no Requests, say) don't skew the mean against the
+// stddev computed around it.
 func (t table) meanQuantities(column func(i int) *resource.Quantity) *resource.Quantity {
-	sum := t.sumQuantities(column)
-	dec := sum.AsDec()
+	values := t.collectQuantities(column)
+	if len(values) == 0 {
+		return nil
+	}
+
+	sum := new(inf.Dec)
+	for _, v := range values {
+		sum.Add(sum, v.AsDec())
+	}
 	tmp := inf.Dec{}
-	tmp.QuoRound(dec, inf.NewDec(int64(len(t)), 0), dec.Scale(), inf.RoundDown)
+	tmp.QuoRound(sum, inf.NewDec(int64(len(values)), 0), sum.Scale(), inf.RoundDown)
 
 	return resource.NewDecimalQuantity(tmp, resource.DecimalSI)
 }
@@ -0,0 +1,140 @@
+package cli
+
+import "math"
+
+const (
+	trendArrowUp   = "↑"
+	trendArrowDown = "↓"
+	trendArrowFlat = "→"
+
+	// kzWindow and kzIterations are the base KZ filter parameters (a
+	// window-7, 3-pass filter is the common default for noisy percentage
+	// series). kza derives its adaptive pass's starting half-window from
+	// kzWindow directly (see baseHalf in kza); it needs to be bigger than
+	// 1 or the adaptive shrink has no room to act and degenerates into a
+	// plain moving average.
+	kzWindow      = 7
+	kzIterations  = 3
+	kzaDerivQuant = 3 // q: how far out KZA looks to estimate the local derivative
+)
+
+// movingAverage returns the centered simple moving average of series with
+// the given window, shrinking the window near the edges instead of padding.
+func movingAverage(series []float64, window int) []float64 {
+	n := len(series)
+	out := make([]float64, n)
+	half := window / 2
+
+	for i := 0; i < n; i++ {
+		lo, hi := i-half, i+half
+		if lo < 0 {
+			lo = 0
+		}
+		if hi >= n {
+			hi = n - 1
+		}
+		sum := 0.0
+		for j := lo; j <= hi; j++ {
+			sum += series[j]
+		}
+		out[i] = sum / float64(hi-lo+1)
+	}
+	return out
+}
+
+// kz applies the base Kolmogorov-Zurbenko filter: a window-m moving
+// average repeated k times.
+func kz(series []float64, m, k int) []float64 {
+	out := series
+	for i := 0; i < k; i++ {
+		out = movingAverage(out, m)
+	}
+	return out
+}
+
+// kza applies adaptive KZ (KZA) smoothing: the base KZ filter followed by
+// a second pass whose half-window shrinks at points where the KZ-smoothed
+// series has a large local derivative, so real trend changes aren't
+// smeared out along with the noise.
+func kza(series []float64, m, k, q int) []float64 {
+	n := len(series)
+	if n == 0 {
+		return series
+	}
+
+	base := kz(series, m, k)
+
+	baseHalf := m / 2
+	if baseHalf < 1 {
+		baseHalf = 1
+	}
+
+	deriv := make([]float64, n)
+	maxDeriv := 0.0
+	for i := 0; i < n; i++ {
+		lo, hi := i-q, i+q
+		if lo < 0 {
+			lo = 0
+		}
+		if hi >= n {
+			hi = n - 1
+		}
+		d := math.Abs(base[hi] - base[lo])
+		deriv[i] = d
+		if d > maxDeriv {
+			maxDeriv = d
+		}
+	}
+
+	out := make([]float64, n)
+	for i := 0; i < n; i++ {
+		normalized := 0.0
+		if maxDeriv > 0 {
+			normalized = deriv[i] / maxDeriv
+		}
+		half := int(math.Round(float64(baseHalf) * (1 - normalized)))
+		if half < 1 {
+			half = 1
+		}
+
+		lo, hi := i-half, i+half
+		if lo < 0 {
+			lo = 0
+		}
+		if hi >= n {
+			hi = n - 1
+		}
+		sum := 0.0
+		for j := lo; j <= hi; j++ {
+			sum += series[j]
+		}
+		out[i] = sum / float64(hi-lo+1)
+	}
+	return out
+}
+
+// trendArrow reports whether smoothed is trending up, down, or flat,
+// based on the sign of its last-minus-first value.
+func trendArrow(smoothed []float64) string {
+	if len(smoothed) < 2 {
+		return trendArrowFlat
+	}
+	switch delta := smoothed[len(smoothed)-1] - smoothed[0]; {
+	case delta > 0:
+		return trendArrowUp
+	case delta < 0:
+		return trendArrowDown
+	default:
+		return trendArrowFlat
+	}
+}
+
+// computeTrend smooths a per-(namespace,name) % diff series with KZA and
+// returns the smoothed series alongside its trend arrow.
+func computeTrend(series []float64) (smoothed []float64, arrow string) {
+	if len(series) == 0 {
+		return nil, trendArrowFlat
+	}
+	smoothed = kza(series, kzWindow, kzIterations, kzaDerivQuant)
+	return smoothed, trendArrow(smoothed)
+}
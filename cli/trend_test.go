@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"math"
+	"testing"
+)
+
+// approxEqualSeries reports whether a and b are the same length and
+// element-wise within eps of each other.
+func approxEqualSeries(t *testing.T, a, b []float64, eps float64) bool {
+	t.Helper()
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > eps {
+			return false
+		}
+	}
+	return true
+}
+
+func TestComputeTrendFlat(t *testing.T) {
+	series := []float64{5, 5, 5, 5, 5, 5, 5, 5, 5, 5}
+
+	smoothed, arrow := computeTrend(series)
+	if arrow != trendArrowFlat {
+		t.Errorf("expected %q, got %q", trendArrowFlat, arrow)
+	}
+
+	// A flat series has zero derivative everywhere, so the adaptive pass
+	// should never shrink the window: kza should match a plain
+	// movingAverage at the same base window.
+	want := movingAverage(series, kzWindow)
+	if !approxEqualSeries(t, smoothed, want, 1e-9) {
+		t.Errorf("expected window to stay full on a flat series: got %v, want %v", smoothed, want)
+	}
+}
+
+func TestComputeTrendRamp(t *testing.T) {
+	tests := []struct {
+		name   string
+		series []float64
+		arrow  string
+	}{
+		{
+			name:   "ramp up",
+			series: []float64{0, 2, 4, 6, 8, 10, 12, 14, 16, 18, 20},
+			arrow:  trendArrowUp,
+		},
+		{
+			name:   "ramp down",
+			series: []float64{20, 18, 16, 14, 12, 10, 8, 6, 4, 2, 0},
+			arrow:  trendArrowDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			smoothed, arrow := computeTrend(tt.series)
+			if arrow != tt.arrow {
+				t.Errorf("expected %q, got %q", tt.arrow, arrow)
+			}
+			if len(smoothed) != len(tt.series) {
+				t.Errorf("expected smoothed series of length %d, got %d", len(tt.series), len(smoothed))
+			}
+		})
+	}
+}
+
+func TestComputeTrendShortSeries(t *testing.T) {
+	// Shorter than kzWindow, so every point's window is clamped to the
+	// whole series: every output point averages over all of it, so the
+	// smoothed series is constant and flat regardless of the input's
+	// underlying direction.
+	series := []float64{1, 5, 10}
+
+	smoothed, arrow := computeTrend(series)
+	if len(smoothed) != len(series) {
+		t.Errorf("expected smoothed series of length %d, got %d", len(series), len(smoothed))
+	}
+	for i := 1; i < len(smoothed); i++ {
+		if math.Abs(smoothed[i]-smoothed[0]) > 1e-9 {
+			t.Errorf("expected a clamped short series to smooth to a constant, got %v", smoothed)
+			break
+		}
+	}
+	if arrow != trendArrowFlat {
+		t.Errorf("expected %q, got %q", trendArrowFlat, arrow)
+	}
+}
+
+func TestComputeTrendEmpty(t *testing.T) {
+	smoothed, arrow := computeTrend(nil)
+	if smoothed != nil {
+		t.Errorf("expected nil smoothed series, got %v", smoothed)
+	}
+	if arrow != trendArrowFlat {
+		t.Errorf("expected %q, got %q", trendArrowFlat, arrow)
+	}
+}